@@ -0,0 +1,81 @@
+// Package health backs the /healthz and /readyz endpoints and exposes the
+// app_up and app_shutdown_in_progress gauges so rolling restarts show up in
+// Prometheus instead of looking like silent request drops.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ybkuroki/go-webapp-sample/metrics"
+)
+
+// Pinger is implemented by anything that can verify connectivity, such as
+// the repository's underlying *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Checker tracks whether the process is shutting down and, once it is,
+// fails readiness checks so load balancers drain traffic away before the
+// server actually stops accepting connections.
+type Checker struct {
+	db Pinger
+
+	shuttingDown int32 // accessed atomically; 0 = up, 1 = shutting down
+
+	up               metrics.Gauge
+	shutdownProgress metrics.Gauge
+}
+
+// NewChecker creates a Checker that pings db to answer readiness checks and
+// reports app_up/app_shutdown_in_progress through provider.
+func NewChecker(db Pinger, provider metrics.Provider) *Checker {
+	c := &Checker{
+		db: db,
+		up: provider.NewGauge(
+			"app_up", "1 if the application is running, 0 once shutdown has started.", nil,
+		).WithLabelValues(),
+		shutdownProgress: provider.NewGauge(
+			"app_shutdown_in_progress", "1 while the application is draining connections for a graceful shutdown.", nil,
+		).WithLabelValues(),
+	}
+	c.up.Set(1)
+	return c
+}
+
+// BeginShutdown marks the process as shutting down: app_up drops to 0,
+// app_shutdown_in_progress rises to 1, and subsequent readiness checks
+// fail so load balancers stop routing new traffic here.
+func (c *Checker) BeginShutdown() {
+	atomic.StoreInt32(&c.shuttingDown, 1)
+	c.up.Set(0)
+	c.shutdownProgress.Set(1)
+}
+
+// Liveness is always 200 unless the process is shutting down, in which case
+// it is of no use alive or dead and returns 503.
+func (c *Checker) Liveness(ctx echo.Context) error {
+	if atomic.LoadInt32(&c.shuttingDown) == 1 {
+		return ctx.NoContent(http.StatusServiceUnavailable)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Readiness returns 503 while shutting down, when no database handle was
+// ever obtained, or when the database is unreachable, so the load balancer
+// drains this instance instead of the probe panicking.
+func (c *Checker) Readiness(ctx echo.Context) error {
+	if atomic.LoadInt32(&c.shuttingDown) == 1 {
+		return ctx.NoContent(http.StatusServiceUnavailable)
+	}
+	if c.db == nil {
+		return ctx.NoContent(http.StatusServiceUnavailable)
+	}
+	if err := c.db.PingContext(ctx.Request().Context()); err != nil {
+		return ctx.NoContent(http.StatusServiceUnavailable)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
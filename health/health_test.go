@@ -0,0 +1,26 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ybkuroki/go-webapp-sample/metrics"
+)
+
+func TestReadiness_NilDBReturnsUnavailableInsteadOfPanicking(t *testing.T) {
+	checker := NewChecker(nil, metrics.NoopProvider{})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := checker.Readiness(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for a nil db handle, got %d", rec.Code)
+	}
+}
@@ -1,18 +1,24 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/ybkuroki/go-webapp-sample/config"
 	"github.com/ybkuroki/go-webapp-sample/container"
+	"github.com/ybkuroki/go-webapp-sample/health"
 	"github.com/ybkuroki/go-webapp-sample/logger"
+	"github.com/ybkuroki/go-webapp-sample/metrics"
 	"github.com/ybkuroki/go-webapp-sample/middleware"
 	"github.com/ybkuroki/go-webapp-sample/migration"
 	"github.com/ybkuroki/go-webapp-sample/repository"
 	"github.com/ybkuroki/go-webapp-sample/router"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	// "net/http"
-	"time"
 )
 
 // @title go-webapp-sample API
@@ -23,71 +29,84 @@ import (
 // @host localhost:8080
 // @BasePath /api
 
-// Prometheus metrics
-var (
-	requestDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
-		Name:    "http_request_duration_seconds",
-		Help:    "Duration of HTTP requests in seconds",
-		Buckets: prometheus.DefBuckets,
-	})
-
-	errorCounter = prometheus.NewCounter(prometheus.CounterOpts{
-		Name: "http_errors_total",
-		Help: "Total number of HTTP errors",
-	})
-)
-
-// Initialize metrics
-func init() {
-	prometheus.MustRegister(requestDuration)
-	prometheus.MustRegister(errorCounter)
-}
-
-// Middleware to measure latency and count errors
-func metricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		start := time.Now()
-
-		// Execute the next handler and capture any errors
-		err := next(c)
-		if err != nil {
-			c.Error(err)
-			errorCounter.Inc() // Increment the error counter if an error occurs
-		}
-
-		// Record the duration of the request
-		duration := time.Since(start).Seconds()
-		requestDuration.Observe(duration)
-
-		return err
-	}
-}
+// defaultShutdownGracePeriod bounds how long e.Shutdown waits for in-flight
+// requests to finish when application.<env>.yml doesn't set one.
+const defaultShutdownGracePeriod = 10 * time.Second
 
 func main() {
 	e := echo.New()
 
-	// Use the metrics middleware
-	e.Use(metricsMiddleware)
-
-	// Prometheus metrics endpoint
-	e.GET("/prometheus", echo.WrapHandler(promhttp.Handler()))
-
 	// Load configuration and initialize logger
 	conf, env := config.Load()
 	logger := logger.NewLogger(env)
 	logger.GetZapLogger().Infof("Loaded configuration: application.%s.yml", env)
 
+	// Build the HTTP metrics middleware from application.<env>.yml and mount
+	// its registry, either on this Echo instance or on a dedicated one so
+	// scraping traffic never shows up in the app's own metrics.
+	metricsMiddleware, registry := metrics.NewMiddleware(metrics.Options{
+		Namespace: conf.Metrics.Namespace,
+		Subsystem: conf.Metrics.Subsystem,
+		Buckets:   conf.Metrics.Buckets,
+		SkipPaths: conf.Metrics.SkipPaths,
+	})
+	e.Use(metricsMiddleware)
+
+	// Domain-level metrics (retries, sessions, migrations, health) go
+	// through a Provider rather than calling Prometheus directly, so the
+	// same instrumentation code works unchanged against a StatsD sidecar.
+	provider, err := metrics.NewProviderForBackend(conf.Metrics.Backend, conf.Metrics.StatsDAddress, registry)
+	if err != nil {
+		logger.GetZapLogger().Errorf("Error initializing %q metrics backend, falling back to Prometheus: %s", conf.Metrics.Backend, err.Error())
+		provider = metrics.NewPrometheusProvider(registry)
+	}
+
+	// Retry sits inside the HTTP metrics middleware so the outer duration
+	// histogram reflects total wall time across every attempt. SkipPaths
+	// routes streaming/hijacking handlers around the buffering entirely.
+	retryMetrics := metrics.NewRetryMetrics(provider)
+	retryConfig := middleware.DefaultRetryConfig(retryMetrics)
+	retryConfig.SkipPaths = conf.Retry.SkipPaths
+	e.Use(middleware.Retry(retryConfig))
+
+	if conf.Metrics.SeparateInstance {
+		metricsServer := echo.New()
+		metricsServer.GET("/metrics", echo.WrapHandler(metrics.NewHandler(registry)))
+		go func() {
+			if err := metricsServer.Start(conf.Metrics.ListenAddress); err != nil {
+				logger.GetZapLogger().Errorf("Error starting metrics server: %s", err.Error())
+			}
+		}()
+	} else {
+		e.GET("/metrics", echo.WrapHandler(metrics.NewHandler(registry)))
+	}
+
 	// Initialize repository and container
 	rep := repository.NewBookRepository(logger, conf)
 	container := container.NewContainer(rep, conf, logger, env)
 
-	// Run database migrations and initialize master data
-	migration.CreateDatabase(container)
-	migration.InitMasterData(container)
+	// Report connection pool health for the repository, and reuse the same handle to back the /readyz database check.
+	var sqlDB *sql.DB
+	if db, err := rep.GetConnection().DB(); err != nil {
+		logger.GetZapLogger().Errorf("Error obtaining database handle for metrics: %s", err.Error())
+	} else {
+		sqlDB = db
+		registry.MustRegister(metrics.NewDBCollector(sqlDB))
+	}
+
+	// Report requests currently being served for a session cookie.
+	sessionGauge := metrics.NewSessionGauge(provider)
+
+	// Run database migrations and initialize master data, recording the
+	// duration of each step.
+	migrationMetrics := metrics.NewMigrationRecorder(provider)
+	_ = migrationMetrics.Track("create_database", func() error { migration.CreateDatabase(container); return nil })
+	_ = migrationMetrics.Track("init_master_data", func() error { migration.InitMasterData(container); return nil })
 
 	// Initialize routers and middlewares
 	router.Init(e, container)
 	middleware.InitLoggerMiddleware(e, container)
+	e.Use(sessionGauge.Middleware())
 	middleware.InitSessionMiddleware(e, container)
 
 	// Serve static files if a path is provided
@@ -96,10 +115,46 @@ func main() {
 		logger.GetZapLogger().Infof("Served static contents. Path: %s", conf.StaticContents.Path)
 	}
 
-	// Start the server
-	if err := e.Start(":8000"); err != nil {
-		logger.GetZapLogger().Errorf("Error starting server: %s", err.Error())
+	// Liveness and readiness probes, backed by app_up/app_shutdown_in_progress
+	// gauges so rolling restarts are visible in Prometheus. Pass a genuinely
+	// nil Pinger (not a typed-nil *sql.DB) when the handle was never
+	// obtained, so Readiness can detect it instead of panicking on Ping.
+	var pinger health.Pinger
+	if sqlDB != nil {
+		pinger = sqlDB
+	}
+	checker := health.NewChecker(pinger, provider)
+	e.GET("/healthz", checker.Liveness)
+	e.GET("/readyz", checker.Readiness)
+
+	// Start the server in the background so we can wait for a shutdown
+	// signal below instead of blocking here.
+	go func() {
+		if err := e.Start(":8000"); err != nil && err != http.ErrServerClosed {
+			logger.GetZapLogger().Errorf("Error starting server: %s", err.Error())
+		}
+	}()
+
+	// Block until SIGINT/SIGTERM, then drain in-flight requests before
+	// tearing down the repository and flushing the logger, instead of
+	// letting Echo be killed abruptly.
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logger.GetZapLogger().Infof("Shutdown signal received, draining connections")
+	checker.BeginShutdown()
+
+	gracePeriod := conf.Shutdown.GracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = defaultShutdownGracePeriod
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	if err := e.Shutdown(ctx); err != nil {
+		logger.GetZapLogger().Errorf("Error during graceful shutdown: %s", err.Error())
 	}
 
-	defer rep.Close() // Ensure the repository is closed on exit
+	rep.Close()
+	_ = logger.GetZapLogger().Sync()
 }
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DBCollector is a prometheus.Collector that reports connection pool health
+// for a *sql.DB, sampled on every scrape via sql.DB.Stats().
+//
+// This does not cover the per-operation/table query-latency histogram and
+// query-error counter the original request asked for
+// (db_query_duration_seconds, db_query_errors_total): that requires
+// instrumenting call sites inside the repository package, which this
+// snapshot of the tree does not include. Treat that part of the request as
+// not yet delivered rather than implemented and silently dropped.
+type DBCollector struct {
+	db *sql.DB
+
+	openConnections *prometheus.Desc
+	inUse           *prometheus.Desc
+	idle            *prometheus.Desc
+}
+
+// NewDBCollector creates a DBCollector sampling the given connection pool.
+// Callers must register the returned collector, e.g. registry.MustRegister.
+func NewDBCollector(db *sql.DB) *DBCollector {
+	return &DBCollector{
+		db: db,
+
+		openConnections: prometheus.NewDesc(
+			"db_connections_open", "Number of established connections to the database.", nil, nil,
+		),
+		inUse: prometheus.NewDesc(
+			"db_connections_in_use", "Number of connections currently in use.", nil, nil,
+		),
+		idle: prometheus.NewDesc(
+			"db_connections_idle", "Number of idle connections.", nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *DBCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openConnections
+	ch <- c.inUse
+	ch <- c.idle
+}
+
+// Collect implements prometheus.Collector.
+func (c *DBCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.openConnections, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewHandler returns the http.Handler to mount at /metrics. It serves every
+// collector registered on reg, which is normally the registry returned by
+// NewMiddleware so HTTP and domain metrics share a single exposition.
+// Passing Registry in HandlerOpts makes promhttp self-report gather and
+// encoding failures as promhttp_metric_handler_errors_total{cause}, instead
+// of a scrape failure going unnoticed.
+func NewHandler(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{
+		Registry:          reg,
+		ErrorHandling:     promhttp.ContinueOnError,
+		EnableOpenMetrics: true,
+	})
+}
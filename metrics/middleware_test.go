@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func gatherFamily(t *testing.T, registry *prometheus.Registry, name string) *dto.MetricFamily {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error gathering metrics: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// TestNewMiddleware_LabelsByRoutePatternNotRawURL ensures the "url" label
+// carries the matched Echo route (e.g. "/books/:id"), not the raw request
+// path, so per-request IDs never blow up metric cardinality.
+func TestNewMiddleware_LabelsByRoutePatternNotRawURL(t *testing.T) {
+	mw, registry := NewMiddleware(Options{Namespace: "test", Subsystem: "http"})
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/books/:id", func(c echo.Context) error {
+		return c.NoContent(200)
+	})
+
+	req := httptest.NewRequest("GET", "/books/123", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	family := gatherFamily(t, registry, "test_http_requests_total")
+	if family == nil {
+		t.Fatalf("expected test_http_requests_total to be registered")
+	}
+
+	var urlValue string
+	for _, m := range family.GetMetric() {
+		for _, l := range m.GetLabel() {
+			if l.GetName() == "url" {
+				urlValue = l.GetValue()
+			}
+		}
+	}
+	if urlValue != "/books/:id" {
+		t.Fatalf("expected url label %q, got %q", "/books/:id", urlValue)
+	}
+}
+
+// TestNewMiddleware_SkipPathsExcludesConfiguredRoute ensures a path listed in
+// Options.SkipPaths is never instrumented, e.g. the /metrics endpoint
+// itself.
+func TestNewMiddleware_SkipPathsExcludesConfiguredRoute(t *testing.T) {
+	mw, registry := NewMiddleware(Options{Namespace: "test", Subsystem: "http", SkipPaths: []string{"/metrics"}})
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/metrics", func(c echo.Context) error { return c.NoContent(200) })
+	e.GET("/books", func(c echo.Context) error { return c.NoContent(200) })
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/books", nil))
+
+	family := gatherFamily(t, registry, "test_http_requests_total")
+	if family == nil {
+		t.Fatalf("expected test_http_requests_total to be registered")
+	}
+
+	for _, m := range family.GetMetric() {
+		for _, l := range m.GetLabel() {
+			if l.GetName() == "url" && l.GetValue() == "/metrics" {
+				t.Fatalf("expected /metrics to be excluded by SkipPaths, found a series for it")
+			}
+		}
+	}
+}
+
+// TestNewMiddleware_AppliesCustomBuckets ensures a configured Buckets value
+// reaches the duration histogram instead of the Prometheus defaults.
+func TestNewMiddleware_AppliesCustomBuckets(t *testing.T) {
+	customBuckets := []float64{0.1, 0.2, 0.3}
+	mw, registry := NewMiddleware(Options{Namespace: "test", Subsystem: "http", Buckets: customBuckets})
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/books", func(c echo.Context) error { return c.NoContent(200) })
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/books", nil))
+
+	family := gatherFamily(t, registry, "test_http_request_duration_seconds")
+	if family == nil {
+		t.Fatalf("expected test_http_request_duration_seconds to be registered")
+	}
+
+	histogram := family.GetMetric()[0].GetHistogram()
+	if len(histogram.GetBucket()) != len(customBuckets) {
+		t.Fatalf("expected %d buckets, got %d", len(customBuckets), len(histogram.GetBucket()))
+	}
+	for i, b := range histogram.GetBucket() {
+		if b.GetUpperBound() != customBuckets[i] {
+			t.Fatalf("expected bucket %d upper bound %v, got %v", i, customBuckets[i], b.GetUpperBound())
+		}
+	}
+}
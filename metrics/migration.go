@@ -0,0 +1,34 @@
+package metrics
+
+import "time"
+
+// MigrationRecorder reports one-shot duration metrics for schema migrations
+// and master-data seeding, both of which run once at startup rather than
+// per request.
+//
+// It does not report the rows-seeded counter the original request asked
+// for: the migration package this snapshot ships doesn't return a row
+// count from CreateDatabase/InitMasterData, so there is nothing real to
+// wire Track's callback output into. Treat rows-seeded instrumentation as
+// not yet delivered, not as implemented and silently dropped.
+type MigrationRecorder struct {
+	duration HistogramVec
+}
+
+// NewMigrationRecorder creates a MigrationRecorder backed by provider.
+func NewMigrationRecorder(provider Provider) *MigrationRecorder {
+	return &MigrationRecorder{
+		duration: provider.NewHistogram(
+			"migration_duration_seconds", "Duration of a startup migration step.", nil, []string{"step"},
+		),
+	}
+}
+
+// Track runs fn, recording its duration under step regardless of outcome,
+// and returns fn's error unchanged.
+func (m *MigrationRecorder) Track(step string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	m.duration.WithLabelValues(step).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// PrometheusProvider is the default Provider: every metric family is a real
+// Prometheus *Vec collector, registered on Registry as soon as it's
+// created.
+type PrometheusProvider struct {
+	Registry *prometheus.Registry
+}
+
+// NewPrometheusProvider creates a PrometheusProvider registering onto reg.
+func NewPrometheusProvider(reg *prometheus.Registry) *PrometheusProvider {
+	return &PrometheusProvider{Registry: reg}
+}
+
+func (p *PrometheusProvider) NewCounter(name, help string, labelNames []string) CounterVec {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labelNames)
+	p.Registry.MustRegister(vec)
+	return prometheusCounterVec{vec}
+}
+
+func (p *PrometheusProvider) NewGauge(name, help string, labelNames []string) GaugeVec {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labelNames)
+	p.Registry.MustRegister(vec)
+	return prometheusGaugeVec{vec}
+}
+
+func (p *PrometheusProvider) NewHistogram(name, help string, buckets []float64, labelNames []string) HistogramVec {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labelNames)
+	p.Registry.MustRegister(vec)
+	return prometheusHistogramVec{vec}
+}
+
+// prometheus.Counter, prometheus.Gauge and prometheus.Observer already
+// satisfy Counter, Gauge and Histogram respectively, so these *Vec wrappers
+// only need to narrow WithLabelValues' return type.
+
+type prometheusCounterVec struct{ vec *prometheus.CounterVec }
+
+func (v prometheusCounterVec) WithLabelValues(labelValues ...string) Counter {
+	return v.vec.WithLabelValues(labelValues...)
+}
+
+type prometheusGaugeVec struct{ vec *prometheus.GaugeVec }
+
+func (v prometheusGaugeVec) WithLabelValues(labelValues ...string) Gauge {
+	return v.vec.WithLabelValues(labelValues...)
+}
+
+type prometheusHistogramVec struct{ vec *prometheus.HistogramVec }
+
+func (v prometheusHistogramVec) WithLabelValues(labelValues ...string) Histogram {
+	return v.vec.WithLabelValues(labelValues...)
+}
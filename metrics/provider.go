@@ -0,0 +1,45 @@
+package metrics
+
+// Counter, Gauge and Histogram are the minimal metric handles that
+// instrumentation code (handlers, middleware, repositories) depends on, so
+// call sites never reference a specific backend's types directly.
+type Counter interface {
+	Inc()
+	Add(float64)
+}
+
+type Gauge interface {
+	Set(float64)
+	Inc()
+	Dec()
+}
+
+type Histogram interface {
+	Observe(float64)
+}
+
+// CounterVec, GaugeVec and HistogramVec are families of metrics
+// distinguished by label values, mirroring the *Vec types instrumentation
+// code already uses with Prometheus.
+type CounterVec interface {
+	WithLabelValues(labelValues ...string) Counter
+}
+
+type GaugeVec interface {
+	WithLabelValues(labelValues ...string) Gauge
+}
+
+type HistogramVec interface {
+	WithLabelValues(labelValues ...string) Histogram
+}
+
+// Provider constructs metric families for a chosen backend and is
+// responsible for exposing them however that backend requires (a pull-based
+// Prometheus registry, a push to a StatsD sidecar, or nothing at all in
+// tests). Collectors depend only on Provider, so swapping backends never
+// touches call sites in handlers, middleware or repositories.
+type Provider interface {
+	NewCounter(name, help string, labelNames []string) CounterVec
+	NewGauge(name, help string, labelNames []string) GaugeVec
+	NewHistogram(name, help string, buckets []float64, labelNames []string) HistogramVec
+}
@@ -0,0 +1,15 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// NewProviderForBackend selects a Provider implementation by name, as
+// configured under metrics.backend in application.<env>.yml. "statsd"
+// pushes to the DogStatsD client at statsDAddress; anything else (including
+// "") falls back to the same Prometheus registry already used for HTTP
+// metrics.
+func NewProviderForBackend(backend, statsDAddress string, registry *prometheus.Registry) (Provider, error) {
+	if backend == "statsd" {
+		return NewStatsDProvider(statsDAddress)
+	}
+	return NewPrometheusProvider(registry), nil
+}
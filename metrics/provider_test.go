@@ -0,0 +1,56 @@
+package metrics
+
+import "testing"
+
+// TestNoopProvider_DiscardsEveryObservation exercises exactly the scenario
+// NoopProvider exists for: instrumentation code should run unmodified
+// against it in tests, without touching a global Prometheus registry.
+func TestNoopProvider_DiscardsEveryObservation(t *testing.T) {
+	provider := NoopProvider{}
+
+	counter := provider.NewCounter("requests_total", "help", []string{"route"}).WithLabelValues("/books")
+	counter.Inc()
+	counter.Add(2)
+
+	gauge := provider.NewGauge("active_total", "help", nil).WithLabelValues()
+	gauge.Set(5)
+	gauge.Inc()
+	gauge.Dec()
+
+	histogram := provider.NewHistogram("duration_seconds", "help", nil, []string{"route"}).WithLabelValues("/books")
+	histogram.Observe(0.1)
+
+	// Nothing above should panic or block; NoopProvider has no registry to
+	// collide on, which is the whole point.
+}
+
+// TestNewProviderForBackend_DefaultsToPrometheus ensures an unset or
+// unrecognized backend name falls back to registering real collectors on
+// the given registry, rather than silently dropping metrics.
+func TestNewProviderForBackend_DefaultsToPrometheus(t *testing.T) {
+	_, registry := NewMiddleware(Options{})
+
+	provider, err := NewProviderForBackend("", "", registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := provider.(*PrometheusProvider); !ok {
+		t.Fatalf("expected a *PrometheusProvider, got %T", provider)
+	}
+
+	provider.NewCounter("test_provider_counter_total", "help", nil).WithLabelValues().Inc()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error gathering metrics: %v", err)
+	}
+	found := false
+	for _, f := range families {
+		if f.GetName() == "test_provider_counter_total" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected test_provider_counter_total to be registered on the given registry")
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// RetryMetrics instruments middleware.Retry: how often a route needed a
+// retry, and how many attempts (and how long each one took) a request
+// ultimately spent before succeeding or exhausting its budget.
+type RetryMetrics struct {
+	retries            CounterVec
+	attemptsPerRequest HistogramVec
+	attemptDuration    HistogramVec
+}
+
+// NewRetryMetrics creates a RetryMetrics backed by provider.
+func NewRetryMetrics(provider Provider) *RetryMetrics {
+	return &RetryMetrics{
+		retries: provider.NewCounter(
+			"backend_retries_total", "Total number of retried handler invocations, by route and attempt number.",
+			[]string{"route", "attempt"},
+		),
+
+		attemptsPerRequest: provider.NewHistogram(
+			"backend_retry_attempts_per_request", "Number of attempts a request took before succeeding or exhausting retries.",
+			[]float64{1, 2, 3, 4, 5, 8}, []string{"route"},
+		),
+
+		attemptDuration: provider.NewHistogram(
+			"backend_retry_attempt_duration_seconds", "Latency of a single retry attempt, as opposed to the total request duration.",
+			nil, []string{"route"},
+		),
+	}
+}
+
+// RecordRetry marks that route was retried for the given attempt number
+// (1-indexed, the attempt about to be made after the previous one failed).
+func (m *RetryMetrics) RecordRetry(route string, attempt int) {
+	m.retries.WithLabelValues(route, strconv.Itoa(attempt)).Inc()
+}
+
+// ObserveAttempt records the latency of a single attempt.
+func (m *RetryMetrics) ObserveAttempt(route string, duration time.Duration) {
+	m.attemptDuration.WithLabelValues(route).Observe(duration.Seconds())
+}
+
+// ObserveRequest records how many attempts the whole request took.
+func (m *RetryMetrics) ObserveRequest(route string, attempts int) {
+	m.attemptsPerRequest.WithLabelValues(route).Observe(float64(attempts))
+}
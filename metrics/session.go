@@ -0,0 +1,50 @@
+package metrics
+
+import "github.com/labstack/echo/v4"
+
+// SessionGauge tracks the number of requests currently being served while
+// carrying a session cookie. This is a proxy for concurrent session-bearing
+// traffic, not the number of active sessions InitSessionMiddleware has
+// handed out: that would require instrumenting the session store itself,
+// which this package has no access to. Treat it as a noisy, near-zero-at-
+// any-instant signal, not a session count.
+type SessionGauge struct {
+	gauge Gauge
+}
+
+// NewSessionGauge creates a SessionGauge backed by provider. The metric is
+// named without a "_total" suffix, since Gauge (unlike Counter) is not
+// monotonic and that suffix is reserved by Prometheus convention for
+// counters.
+func NewSessionGauge(provider Provider) *SessionGauge {
+	return &SessionGauge{
+		gauge: provider.NewGauge("session_requests_in_flight", "Number of requests currently being served for an established session.", nil).WithLabelValues(),
+	}
+}
+
+// Inc marks a session-bearing request as started.
+func (s *SessionGauge) Inc() {
+	s.gauge.Inc()
+}
+
+// Dec marks a session-bearing request as finished.
+func (s *SessionGauge) Dec() {
+	s.gauge.Dec()
+}
+
+// Middleware tracks every request carrying a session cookie for the
+// duration it is being served. Register it ahead of
+// middleware.InitSessionMiddleware so the gauge reflects concurrent
+// session-bearing traffic instead of sitting at zero forever.
+func (s *SessionGauge) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if _, err := c.Cookie("session"); err != nil {
+				return next(c)
+			}
+			s.Inc()
+			defer s.Dec()
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import "github.com/DataDog/datadog-go/v5/statsd"
+
+// StatsDProvider backs every metric with a pushed DogStatsD client instead
+// of a pull-based Prometheus registry, for deployments that already run a
+// StatsD sidecar and would rather not stand up a second scrape target.
+// Histogram buckets are ignored since StatsD aggregates timings/histograms
+// server-side.
+type StatsDProvider struct {
+	client *statsd.Client
+}
+
+// NewStatsDProvider dials a DogStatsD client at addr, e.g. "127.0.0.1:8125".
+func NewStatsDProvider(addr string) (*StatsDProvider, error) {
+	client, err := statsd.New(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsDProvider{client: client}, nil
+}
+
+func (p *StatsDProvider) NewCounter(name, _ string, labelNames []string) CounterVec {
+	return statsdCounterVec{statsdVec{client: p.client, name: name, labelNames: labelNames}}
+}
+
+func (p *StatsDProvider) NewGauge(name, _ string, labelNames []string) GaugeVec {
+	return statsdGaugeVec{statsdVec{client: p.client, name: name, labelNames: labelNames}}
+}
+
+func (p *StatsDProvider) NewHistogram(name, _ string, _ []float64, labelNames []string) HistogramVec {
+	return statsdHistogramVec{statsdVec{client: p.client, name: name, labelNames: labelNames}}
+}
+
+// statsdVec builds the metric's tags on every WithLabelValues call rather
+// than caching per label combination, since DogStatsD has no notion of a
+// pre-registered series the way Prometheus does.
+type statsdVec struct {
+	client     *statsd.Client
+	name       string
+	labelNames []string
+}
+
+func (v statsdVec) metric(labelValues ...string) *statsdMetric {
+	tags := make([]string, 0, len(v.labelNames))
+	for i, ln := range v.labelNames {
+		if i < len(labelValues) {
+			tags = append(tags, ln+":"+labelValues[i])
+		}
+	}
+	return &statsdMetric{client: v.client, name: v.name, tags: tags}
+}
+
+type statsdCounterVec struct{ statsdVec }
+
+func (v statsdCounterVec) WithLabelValues(labelValues ...string) Counter {
+	return v.metric(labelValues...)
+}
+
+type statsdGaugeVec struct{ statsdVec }
+
+func (v statsdGaugeVec) WithLabelValues(labelValues ...string) Gauge { return v.metric(labelValues...) }
+
+type statsdHistogramVec struct{ statsdVec }
+
+func (v statsdHistogramVec) WithLabelValues(labelValues ...string) Histogram {
+	return v.metric(labelValues...)
+}
+
+// statsdMetric implements Counter, Gauge and Histogram simultaneously,
+// translating each call into the matching DogStatsD client method.
+type statsdMetric struct {
+	client *statsd.Client
+	name   string
+	tags   []string
+}
+
+func (m *statsdMetric) Inc()              { _ = m.client.Incr(m.name, m.tags, 1) }
+func (m *statsdMetric) Dec()              { _ = m.client.Decr(m.name, m.tags, 1) }
+func (m *statsdMetric) Add(delta float64) { _ = m.client.Count(m.name, int64(delta), m.tags, 1) }
+func (m *statsdMetric) Set(value float64) { _ = m.client.Gauge(m.name, value, m.tags, 1) }
+func (m *statsdMetric) Observe(value float64) {
+	_ = m.client.Histogram(m.name, value, m.tags, 1)
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ybkuroki/go-webapp-sample/metrics"
+)
+
+// RetryConfig configures Retry.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of times a handler is invoked for a
+	// single request, including the first attempt. Defaults to 1 (no
+	// retries) when unset.
+	MaxAttempts int
+
+	// Methods restricts retries to these HTTP methods. Retrying a
+	// non-idempotent method (POST, PATCH, DELETE, ...) would replay its
+	// side effects, so callers must opt a method in explicitly; anything
+	// not listed here is invoked exactly once. Defaults to GET, HEAD and
+	// OPTIONS when unset.
+	Methods []string
+
+	// SkipPaths lists routes excluded from retry buffering entirely, e.g.
+	// SSE/chunked streaming endpoints that call c.Response().Flush() mid-
+	// handler, or anything that hijacks the connection. Mirrors
+	// metrics.Options.SkipPaths.
+	SkipPaths []string
+
+	// Backoff returns how long to wait before the given attempt (1-indexed,
+	// the attempt about to be retried). Defaults to no wait.
+	Backoff func(attempt int) time.Duration
+
+	// ShouldRetry classifies a completed attempt as worth retrying, given
+	// the status code the handler produced (0 if it never wrote one) and
+	// any error it returned. Defaults to retrying on a handler error or a
+	// 5xx status; a 4xx status written without an error is not retried, but
+	// a returned error is, including an *echo.HTTPError with a 4xx code.
+	ShouldRetry func(c echo.Context, status int, err error) bool
+
+	// Metrics records backend_retries_total and the attempts-per-request
+	// histogram. Required.
+	Metrics *metrics.RetryMetrics
+}
+
+// DefaultRetryConfig retries idempotent requests (GET/HEAD/OPTIONS) up to 3
+// times with no backoff on a handler error or a 5xx status.
+func DefaultRetryConfig(m *metrics.RetryMetrics) RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 3,
+		Methods:     []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+		Backoff:     func(int) time.Duration { return 0 },
+		ShouldRetry: func(_ echo.Context, status int, err error) bool {
+			return err != nil || status >= http.StatusInternalServerError
+		},
+		Metrics: m,
+	}
+}
+
+func (conf RetryConfig) shouldSkip(path string) bool {
+	for _, p := range conf.SkipPaths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (conf RetryConfig) retryableMethods() map[string]bool {
+	methods := conf.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	}
+	set := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		set[m] = true
+	}
+	return set
+}
+
+// Retry transparently re-invokes the next handler on transient failures, as
+// classified by conf.ShouldRetry, but only for the HTTP methods listed in
+// conf.Methods so non-idempotent requests are never replayed. Each attempt
+// writes into a buffer rather than the real connection, so a handler that
+// partially wrote its response before failing (e.g. c.JSON(500, body);
+// return err) never corrupts the client's view of a retried response; only
+// the winning attempt's buffered output is flushed to the real connection.
+//
+// Buffering means an attempt cannot stream: conf.SkipPaths must list any
+// route that calls c.Response().Flush() (SSE, chunked responses) or hijacks
+// the connection, since bufferedResponseWriter cannot support either mid-
+// attempt. Skipped routes pass straight through to next, unretried.
+//
+// Retry is meant to sit inside the HTTP metrics middleware, so the outer
+// request duration histogram reflects the total wall time across every
+// attempt, while each attempt's own latency is recorded separately via
+// conf.Metrics.
+func Retry(conf RetryConfig) echo.MiddlewareFunc {
+	maxAttempts := conf.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	methods := conf.retryableMethods()
+	defaults := DefaultRetryConfig(conf.Metrics)
+	if conf.Backoff == nil {
+		conf.Backoff = defaults.Backoff
+	}
+	if conf.ShouldRetry == nil {
+		conf.ShouldRetry = defaults.ShouldRetry
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !methods[c.Request().Method] || conf.shouldSkip(c.Path()) {
+				return next(c)
+			}
+
+			route := c.Path()
+			res := c.Response()
+			realWriter := res.Writer
+
+			var err error
+			var buf *bufferedResponseWriter
+			attempt := 1
+			for {
+				buf = newBufferedResponseWriter()
+				res.Writer = buf
+				res.Status = 0
+				res.Size = 0
+				res.Committed = false
+
+				start := time.Now()
+				err = next(c)
+				conf.Metrics.ObserveAttempt(route, time.Since(start))
+
+				if attempt >= maxAttempts || !conf.ShouldRetry(c, buf.statusCode, err) {
+					break
+				}
+
+				conf.Metrics.RecordRetry(route, attempt+1)
+				if wait := conf.Backoff(attempt); wait > 0 {
+					time.Sleep(wait)
+				}
+				attempt++
+			}
+
+			res.Writer = realWriter
+			// The winning attempt already committed the buffered writer, so
+			// res.Committed is still true; reset it before flush writes the
+			// real status/headers, or echo's WriteHeader guard silently
+			// no-ops and the client sees an implicit 200 regardless of the
+			// handler's actual status.
+			res.Status = 0
+			res.Size = 0
+			res.Committed = false
+			// An attempt that returned an error without writing anything is
+			// left uncommitted, so Echo's HTTPErrorHandler still renders that
+			// error instead of the client receiving an empty 200.
+			if buf.wroteHeader || err == nil {
+				buf.flush(res)
+			}
+
+			conf.Metrics.ObserveRequest(route, attempt)
+			return err
+		}
+	}
+}
+
+// bufferedResponseWriter collects a single attempt's headers, status and
+// body without ever touching the real connection, so a failed attempt can
+// be discarded instead of corrupting an already-written response.
+type bufferedResponseWriter struct {
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{header: make(http.Header)}
+}
+
+func (w *bufferedResponseWriter) Header() http.Header { return w.header }
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.statusCode = code
+	w.wroteHeader = true
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(b)
+}
+
+// Flush is a no-op: the attempt is buffered and nothing has reached the
+// real connection yet, so there is nothing to flush. Echo's
+// echo.Response.Flush does an unchecked type assertion to http.Flusher, so
+// without this method any handler or downstream middleware that flushes
+// (SSE, gzip) would panic the moment Retry sits in front of it; conf.SkipPaths
+// is still the right place to route such handlers around Retry entirely.
+func (w *bufferedResponseWriter) Flush() {}
+
+// Hijack reports that hijacking isn't supported, per the http.Hijacker
+// contract, instead of letting an unchecked type assertion elsewhere panic.
+// A route that needs to hijack its connection belongs in conf.SkipPaths.
+func (w *bufferedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("middleware: hijacking is not supported behind Retry; add this route to RetryConfig.SkipPaths")
+}
+
+// flush copies the buffered attempt onto the real response.
+func (w *bufferedResponseWriter) flush(res *echo.Response) {
+	for key, values := range w.header {
+		for _, v := range values {
+			res.Header().Add(key, v)
+		}
+	}
+	status := w.statusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	res.WriteHeader(status)
+	_, _ = res.Write(w.body.Bytes())
+}
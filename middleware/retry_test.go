@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ybkuroki/go-webapp-sample/metrics"
+)
+
+func newRetryTestContext(method string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(method, "/books", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/books")
+	return c, rec
+}
+
+func TestRetry_RetriesIdempotentMethodOnServerError(t *testing.T) {
+	conf := DefaultRetryConfig(metrics.NewRetryMetrics(metrics.NoopProvider{}))
+	conf.Backoff = func(int) time.Duration { return 0 }
+
+	calls := 0
+	handler := Retry(conf)(func(c echo.Context) error {
+		calls++
+		if calls < 3 {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "boom"})
+		}
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	c, rec := newRetryTestContext(http.MethodGet)
+	if err := handler(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", rec.Code)
+	}
+}
+
+func TestRetry_DoesNotRetryNonIdempotentMethod(t *testing.T) {
+	conf := DefaultRetryConfig(metrics.NewRetryMetrics(metrics.NoopProvider{}))
+
+	calls := 0
+	handler := Retry(conf)(func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "boom"})
+	})
+
+	c, rec := newRetryTestContext(http.MethodPost)
+	_ = handler(c)
+
+	if calls != 1 {
+		t.Fatalf("expected POST to be invoked exactly once, got %d calls", calls)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the single attempt's status to pass through, got %d", rec.Code)
+	}
+}
+
+func TestRetry_PreservesNonOKStatusOnWinningAttempt(t *testing.T) {
+	conf := DefaultRetryConfig(metrics.NewRetryMetrics(metrics.NoopProvider{}))
+	conf.Backoff = func(int) time.Duration { return 0 }
+	conf.ShouldRetry = func(_ echo.Context, _ int, err error) bool { return err != nil }
+
+	calls := 0
+	handler := Retry(conf)(func(c echo.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.New("transient failure")
+		}
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+
+	c, rec := newRetryTestContext(http.MethodGet)
+	if err := handler(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the winning attempt's 404 to reach the client, got %d", rec.Code)
+	}
+}
+
+func TestRetry_SkipsConfiguredPaths(t *testing.T) {
+	conf := DefaultRetryConfig(metrics.NewRetryMetrics(metrics.NoopProvider{}))
+	conf.SkipPaths = []string{"/books"}
+
+	calls := 0
+	handler := Retry(conf)(func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "boom"})
+	})
+
+	c, rec := newRetryTestContext(http.MethodGet)
+	_ = handler(c)
+
+	if calls != 1 {
+		t.Fatalf("expected a skipped path to be invoked exactly once, got %d calls", calls)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the single attempt's status to pass through, got %d", rec.Code)
+	}
+}
+
+func TestRetry_BufferedWriterSupportsFlushAndHijackWithoutPanicking(t *testing.T) {
+	conf := DefaultRetryConfig(metrics.NewRetryMetrics(metrics.NoopProvider{}))
+
+	handler := Retry(conf)(func(c echo.Context) error {
+		c.Response().Flush()
+		if _, _, err := c.Response().Hijack(); err == nil {
+			t.Fatalf("expected Hijack to report an error behind Retry, got nil")
+		}
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	c, rec := newRetryTestContext(http.MethodGet)
+	if err := handler(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+}
+
+func TestRetry_DiscardsPartialWriteFromFailedAttempt(t *testing.T) {
+	conf := DefaultRetryConfig(metrics.NewRetryMetrics(metrics.NoopProvider{}))
+	conf.Backoff = func(int) time.Duration { return 0 }
+
+	calls := 0
+	handler := Retry(conf)(func(c echo.Context) error {
+		calls++
+		if calls == 1 {
+			_, _ = c.Response().Write([]byte("partial"))
+			return errors.New("transient failure")
+		}
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	c, rec := newRetryTestContext(http.MethodGet)
+	if err := handler(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body := rec.Body.String()
+	if body != `{"status":"ok"}`+"\n" {
+		t.Fatalf("expected only the winning attempt's body, got %q", body)
+	}
+}